@@ -21,12 +21,18 @@
 package main
 
 import (
+	"bytes"
+	"crypto/sha256"
 	"database/sql"
 	"flag"
 	"fmt"
 	"log"
 	"math/rand"
+	"net/http"
 	"net/url"
+	"os"
+	"os/signal"
+	"sync"
 	"sync/atomic"
 	"time"
 
@@ -34,15 +40,17 @@ import (
 	"github.com/cockroachdb/cockroach/util/uuid"
 )
 
-const (
-	insertBlockStmt = `INSERT INTO blocks (block_id, writer_id, block_num, raw_bytes) VALUES ($1, $2, $3, $4)`
-)
-
 // db-url = URL of database.
 var dbURL = flag.String("db-url", "", "URL to connect to a running cockroach cluster.")
 
-// concurrency = number of concurrent insertion processes.
-var concurrency = flag.Int("concurrency", 3, "Number of concurrent writers inserting blocks.")
+// concurrency = number of concurrent block generators.
+var concurrency = flag.Int("concurrency", 3, "Number of concurrent block generators.")
+
+// writers = number of concurrent DB-writer goroutines draining the queue.
+var writers = flag.Int("writers", 3, "Number of concurrent writers draining the generated block queue.")
+
+// queueDepth = size of the bounded channel between generators and writers.
+var queueDepth = flag.Int("queue-depth", 256, "Size of the bounded queue between block generators and writers.")
 
 var tolerateErrors = flag.Bool("tolerate-errors", false, "Keep running on error")
 
@@ -53,46 +61,350 @@ var outputInterval = flag.Duration("output-interval", 1*time.Second, "Interval o
 var minBlockSizeBytes = flag.Int("min-block-bytes", 256, "Minimum amount of raw data written with each insertion.")
 var maxBlockSizeBytes = flag.Int("max-block-bytes", 1024, "Maximum amount of raw data written with each insertion.")
 
-// numBlocks keeps a global count of successfully written blocks.
-var numBlocks uint64
+// rawSize, when non-zero, overrides minBlockSizeBytes/maxBlockSizeBytes and
+// writes blocks of exactly this many bytes.
+var rawSize = flag.Int("raw-size", 0, "If non-zero, writes blocks of exactly this many bytes, overriding min/max-block-bytes.")
 
-// A blockWriter writes blocks of random data into cockroach in an infinite
-// loop.
-type blockWriter struct {
-	id         string
+// tableCount = number of tables (shards) that writers spread their inserts across.
+var tableCount = flag.Int("table-count", 1, "Number of tables to spread block writes across (blocks_0 .. blocks_{N-1}).")
+
+// batchSize = number of rows written per INSERT statement.
+var batchSize = flag.Int("batch-size", 1, "Number of rows inserted per INSERT statement.")
+
+// readPercent = percentage of concurrency dedicated to verifying previously
+// written blocks instead of generating new ones.
+var readPercent = flag.Int("read-percent", 0, "Percentage (0-100) of concurrency dedicated to reading and verifying previously-written blocks.")
+
+// maxRetries = number of times a failed batch is retried in place, for
+// errors classified as retryable, before its jobs are surfaced to errCh as
+// permanent failures.
+var maxRetries = flag.Int("max-retries", 3, "Maximum number of retries for retryable insert errors before giving up on a batch.")
+
+// listenAddr, when set, serves Prometheus metrics at http://<listenAddr>/metrics.
+var listenAddr = flag.String("listen-addr", "", "If set, serve Prometheus metrics at http://<listen-addr>/metrics.")
+
+// verifyRingSize = number of recently-written blocks remembered for
+// verification by the read/verify workload.
+const verifyRingSize = 4096
+
+// shardStats tracks the throughput and error count of a single shard (table).
+type shardStats struct {
 	blockCount uint64
-	db         *sql.DB
-	rand       *rand.Rand
+	errCount   uint64
 }
 
-func newBlockWriter(db *sql.DB) blockWriter {
-	source := rand.NewSource(int64(time.Now().UnixNano()))
-	return blockWriter{
-		db:   db,
-		id:   uuid.NewUUID4().String(),
-		rand: rand.New(source),
+// blockJob is a single generated block awaiting a write, carrying enough
+// state for the writer to insert it against the correct shard and to later
+// verify it via the read workload.
+type blockJob struct {
+	shardIdx int
+	blockID  int64
+	writerID string
+	blockNum uint64
+	data     []byte
+	sum      [sha256.Size]byte
+}
+
+// writtenBlock identifies a block that was durably written, for sampling by
+// the read/verify workload.
+type writtenBlock struct {
+	shardIdx int
+	blockID  int64
+	writerID string
+	blockNum uint64
+	sum      [sha256.Size]byte
+}
+
+// verifyRing is a fixed-size, concurrency-safe ring buffer of recently
+// written blocks that reader goroutines sample from uniformly.
+type verifyRing struct {
+	mu      sync.Mutex
+	entries []writtenBlock
+	next    int
+	filled  bool
+}
+
+func newVerifyRing(size int) *verifyRing {
+	return &verifyRing{entries: make([]writtenBlock, size)}
+}
+
+func (r *verifyRing) add(wb writtenBlock) {
+	r.mu.Lock()
+	r.entries[r.next] = wb
+	r.next++
+	if r.next == len(r.entries) {
+		r.next = 0
+		r.filled = true
+	}
+	r.mu.Unlock()
+}
+
+// sample returns a uniformly random previously-written block, or false if
+// none have been recorded yet.
+func (r *verifyRing) sample(rnd *rand.Rand) (writtenBlock, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n := r.next
+	if r.filled {
+		n = len(r.entries)
+	}
+	if n == 0 {
+		return writtenBlock{}, false
+	}
+	return r.entries[rnd.Intn(n)], true
+}
+
+// Workload coordinates a pool of block generators and a separate pool of
+// DB-writer goroutines connected by a bounded queue that is drained on shutdown.
+// Generators are sized by concurrency; writers are sized independently by
+// writers, letting callers tune CPU-bound generation separately from the
+// DB-bound write path.
+type Workload struct {
+	db           *sql.DB
+	tableCount   int
+	batchSize    int
+	shards       []*shardStats
+	queue        chan *blockJob
+	queueLen     int64
+	highWater    int64
+	ring         *verifyRing
+	verifyErrors uint64
+	metrics      *metricsRegistry
+}
+
+// newWorkload creates a Workload with one shardStats per table and a queue of
+// the configured depth.
+func newWorkload(db *sql.DB) *Workload {
+	w := &Workload{
+		db:         db,
+		tableCount: *tableCount,
+		batchSize:  *batchSize,
+		shards:     make([]*shardStats, *tableCount),
+		queue:      make(chan *blockJob, *queueDepth),
+		ring:       newVerifyRing(verifyRingSize),
+		metrics:    newMetricsRegistry(),
 	}
+	for i := range w.shards {
+		w.shards[i] = &shardStats{}
+	}
+	return w
 }
 
-// run is an infinite loop in which the blockWriter continuously attempts to
-// write blocks of random data into a table in cockroach DB.
-func (bw blockWriter) run(errCh chan<- error) {
+// runGenerator is an infinite loop (until stopCh closes) in which a
+// blockWriter produces random blocks and pushes them onto the shared queue
+// for the writer pool to consume. Each generator rotates through every
+// shard in turn (offset by generatorIdx) so that all tables receive
+// traffic regardless of how concurrency compares to tableCount.
+func (w *Workload) runGenerator(generatorIdx int, stopCh <-chan struct{}) {
+	bw := newBlockWriter()
+
 	for {
-		blockID := bw.rand.Int63()
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
 		blockData := bw.randomBlock()
+		shardIdx := (generatorIdx + int(bw.blockCount)) % w.tableCount
 		bw.blockCount++
-		if _, err := bw.db.Exec(insertBlockStmt, blockID, bw.id, bw.blockCount, blockData); err != nil {
-			errCh <- fmt.Errorf("error running blockwriter %s: %s", bw.id, err)
+		job := &blockJob{
+			shardIdx: shardIdx,
+			blockID:  bw.rand.Int63(),
+			writerID: bw.id,
+			blockNum: bw.blockCount,
+			data:     blockData,
+			sum:      sha256.Sum256(blockData),
+		}
+		select {
+		case w.queue <- job:
+			n := atomic.AddInt64(&w.queueLen, 1)
+			for {
+				hw := atomic.LoadInt64(&w.highWater)
+				if n <= hw || atomic.CompareAndSwapInt64(&w.highWater, hw, n) {
+					break
+				}
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// insertQuery builds the multi-row "INSERT ... VALUES (...),(...),..."
+// statement text for inserting n rows into table.
+func insertQuery(table string, n int) string {
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "INSERT INTO %s (block_id, writer_id, block_num, raw_bytes) VALUES ", table)
+	for i := 0; i < n; i++ {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k := i * 4
+		fmt.Fprintf(&buf, "($%d, $%d, $%d, $%d)", k+1, k+2, k+3, k+4)
+	}
+	return buf.String()
+}
+
+// batchInsert prepares the multi-row insert statement for exactly batchSize
+// rows against a single shard. Batches shorter than batchSize (the final,
+// partial batch flushed on shutdown) fall back to an ad-hoc query built by
+// insertQuery, since the prepared statement's parameter count is fixed.
+type batchInsert struct {
+	table     string
+	batchSize int
+	stmt      *sql.Stmt
+}
+
+// newBatchInsert prepares a batchSize-row insert statement against table.
+func newBatchInsert(db *sql.DB, table string, batchSize int) (*batchInsert, error) {
+	stmt, err := db.Prepare(insertQuery(table, batchSize))
+	if err != nil {
+		return nil, err
+	}
+	return &batchInsert{table: table, batchSize: batchSize, stmt: stmt}, nil
+}
+
+// exec inserts rows worth of args (4 params per row), using the prepared
+// batchSize statement when rows matches it exactly, or an ad-hoc query for
+// any other row count, such as the final partial batch flushed on shutdown.
+func (b *batchInsert) exec(db *sql.DB, rows int, args []interface{}) (sql.Result, error) {
+	if rows == b.batchSize {
+		return b.stmt.Exec(args...)
+	}
+	return db.Exec(insertQuery(b.table, rows), args...)
+}
+
+// newSelectBlock prepares the lookup statement used by the read/verify
+// workload to fetch a previously-written block's raw bytes back from its
+// shard table.
+func newSelectBlock(db *sql.DB, table string) (*sql.Stmt, error) {
+	return db.Prepare(fmt.Sprintf("SELECT raw_bytes FROM %s WHERE block_id=$1 AND writer_id=$2 AND block_num=$3", table))
+}
+
+// runReader is an infinite loop (until stopCh closes) in which a reader
+// goroutine samples a recently-written block from the ring buffer, re-reads
+// it from its shard table, and verifies the bytes match what was written.
+// Verification failures are tracked separately from SQL errors, which are
+// reported to errCh like any other failure.
+func (w *Workload) runReader(selects []*sql.Stmt, errCh chan<- error, stopCh <-chan struct{}) {
+	rnd := rand.New(rand.NewSource(time.Now().UnixNano()))
+	for {
+		select {
+		case <-stopCh:
+			return
+		default:
+		}
+		wb, ok := w.ring.sample(rnd)
+		if !ok {
+			time.Sleep(10 * time.Millisecond)
+			continue
+		}
+		var rawBytes []byte
+		if err := selects[wb.shardIdx].QueryRow(wb.blockID, wb.writerID, wb.blockNum).Scan(&rawBytes); err != nil {
+			errCh <- fmt.Errorf("error reading block_num=%d writer_id=%s: %s", wb.blockNum, wb.writerID, err)
+			continue
+		}
+		if sha256.Sum256(rawBytes) != wb.sum {
+			atomic.AddUint64(&w.verifyErrors, 1)
+		}
+	}
+}
+
+// runWriter is an infinite loop (until the queue is drained and closed) in
+// which a writer goroutine accumulates jobs per shard and flushes a batch
+// once batchSize jobs have collected for that shard. A batch that fails with
+// a retryable error is retried in place, with exponential backoff and
+// jitter, up to maxRetries times before its jobs are reported to errCh as
+// permanent failures.
+func (w *Workload) runWriter(batches []*batchInsert, errCh chan<- error) {
+	pending := make([][]*blockJob, w.tableCount)
+
+	flush := func(shardIdx int) {
+		jobs := pending[shardIdx]
+		if len(jobs) == 0 {
+			return
+		}
+		stats := w.shards[shardIdx]
+		args := make([]interface{}, 0, len(jobs)*4)
+		for _, job := range jobs {
+			args = append(args, job.blockID, job.writerID, job.blockNum, job.data)
+		}
+
+		start := time.Now()
+		var err error
+		var reason string
+		var attempt int
+		for {
+			if _, err = batches[shardIdx].exec(w.db, len(jobs), args); err == nil {
+				break
+			}
+			var retryable bool
+			retryable, reason = classifyError(err)
+			if !retryable || attempt >= *maxRetries {
+				break
+			}
+			attempt++
+			w.metrics.addRetries(1)
+			time.Sleep(backoff(attempt))
+		}
+		w.metrics.observeLatency(time.Since(start).Seconds())
+		if err != nil {
+			atomic.AddUint64(&stats.errCount, 1)
+			w.metrics.addFailed(reason, uint64(len(jobs)))
+			errCh <- fmt.Errorf("error writing batch of %d blocks to shard %d after %d retries: %s", len(jobs), shardIdx, attempt, err)
 		} else {
-			atomic.AddUint64(&numBlocks, 1)
+			atomic.AddUint64(&stats.blockCount, uint64(len(jobs)))
+			w.metrics.addWritten(uint64(len(jobs)))
+			for _, job := range jobs {
+				w.ring.add(writtenBlock{
+					shardIdx: job.shardIdx,
+					blockID:  job.blockID,
+					writerID: job.writerID,
+					blockNum: job.blockNum,
+					sum:      job.sum,
+				})
+			}
 		}
+		pending[shardIdx] = pending[shardIdx][:0]
+	}
+
+	for job := range w.queue {
+		atomic.AddInt64(&w.queueLen, -1)
+		pending[job.shardIdx] = append(pending[job.shardIdx], job)
+		if len(pending[job.shardIdx]) >= w.batchSize {
+			flush(job.shardIdx)
+		}
+	}
+	// Drain: the queue has been closed and emptied; flush any partial
+	// batches so that no generated block is silently dropped on shutdown.
+	for shardIdx := range pending {
+		flush(shardIdx)
+	}
+}
+
+// A blockWriter generates blocks of random data to be written into cockroach.
+type blockWriter struct {
+	id         string
+	blockCount uint64
+	rand       *rand.Rand
+}
+
+func newBlockWriter() blockWriter {
+	source := rand.NewSource(int64(time.Now().UnixNano()))
+	return blockWriter{
+		id:   uuid.NewUUID4().String(),
+		rand: rand.New(source),
 	}
 }
 
 // randomBlock generates a slice of randomized bytes. Random data is preferred
-// to prevent compression in storage.
+// to prevent compression in storage. If rawSize is set, it is used as the
+// exact block size, overriding min/maxBlockSizeBytes.
 func (bw blockWriter) randomBlock() []byte {
-	blockSize := bw.rand.Intn(*maxBlockSizeBytes-*minBlockSizeBytes) + *minBlockSizeBytes
+	blockSize := *rawSize
+	if blockSize == 0 {
+		blockSize = bw.rand.Intn(*maxBlockSizeBytes-*minBlockSizeBytes) + *minBlockSizeBytes
+	}
 	blockData := make([]byte, blockSize)
 	for i := range blockData {
 		blockData[i] = byte(bw.rand.Int() & 0xff)
@@ -100,9 +412,10 @@ func (bw blockWriter) randomBlock() []byte {
 	return blockData
 }
 
-// setupDatabase performs initial setup for the example, creating a database and
-// with a single table. If the desired table already exists on the cluster, the
-// existing table will be dropped.
+// setupDatabase performs initial setup for the example, creating a database
+// and tableCount shard tables (blocks_0 .. blocks_{tableCount-1}). If the
+// desired tables already exist on the cluster, the existing tables will be
+// dropped.
 func setupDatabase() (*sql.DB, error) {
 	parsedURL, err := url.Parse(*dbURL)
 	if err != nil {
@@ -131,22 +444,25 @@ func setupDatabase() (*sql.DB, error) {
 	if err != nil {
 		return nil, err
 	}
-	// Allow a maximum of concurrency+1 connections to the database.
-	db.SetMaxOpenConns(*concurrency + 1)
+	// Allow a maximum of writers+1 connections to the database.
+	db.SetMaxOpenConns(*writers + 1)
 
-	// Create the initial table for storing blocks.
-	if _, err := db.Exec(`DROP TABLE IF EXISTS blocks`); err != nil {
-		return nil, err
-	}
-	if _, err := db.Exec(`
-	CREATE TABLE IF NOT EXISTS blocks (
-	  block_id BIGINT NOT NULL,
-	  writer_id STRING NOT NULL,
-	  block_num BIGINT NOT NULL,
-	  raw_bytes BYTES NOT NULL,
-	  PRIMARY KEY (block_id, writer_id, block_num)
-	)`); err != nil {
-		return nil, err
+	// Create the shard tables for storing blocks.
+	for i := 0; i < *tableCount; i++ {
+		table := fmt.Sprintf("blocks_%d", i)
+		if _, err := db.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS %s`, table)); err != nil {
+			return nil, err
+		}
+		if _, err := db.Exec(fmt.Sprintf(`
+		CREATE TABLE IF NOT EXISTS %s (
+		  block_id BIGINT NOT NULL,
+		  writer_id STRING NOT NULL,
+		  block_num BIGINT NOT NULL,
+		  raw_bytes BYTES NOT NULL,
+		  PRIMARY KEY (block_id, writer_id, block_num)
+		)`, table)); err != nil {
+			return nil, err
+		}
 	}
 
 	return db, nil
@@ -159,10 +475,38 @@ func main() {
 		log.Fatalf("Value of 'concurrency' flag (%d) must be greater than or equal to 1", *concurrency)
 	}
 
-	if max, min := *maxBlockSizeBytes, *minBlockSizeBytes; max < min {
+	if *writers < 1 {
+		log.Fatalf("Value of 'writers' flag (%d) must be greater than or equal to 1", *writers)
+	}
+
+	if *queueDepth < 1 {
+		log.Fatalf("Value of 'queue-depth' flag (%d) must be greater than or equal to 1", *queueDepth)
+	}
+
+	if *tableCount < 1 {
+		log.Fatalf("Value of 'table-count' flag (%d) must be greater than or equal to 1", *tableCount)
+	}
+
+	if *batchSize < 1 {
+		log.Fatalf("Value of 'batch-size' flag (%d) must be greater than or equal to 1", *batchSize)
+	}
+
+	if *readPercent < 0 || *readPercent > 100 {
+		log.Fatalf("Value of 'read-percent' flag (%d) must be between 0 and 100", *readPercent)
+	}
+
+	if *rawSize < 0 {
+		log.Fatalf("Value of 'raw-size' flag (%d) must be greater than or equal to 0", *rawSize)
+	} else if *rawSize > 0 {
+		log.Printf("'raw-size' is set to %d; overriding 'min-block-bytes' and 'max-block-bytes'", *rawSize)
+	} else if max, min := *maxBlockSizeBytes, *minBlockSizeBytes; max < min {
 		log.Fatalf("Value of 'max-block-bytes' (%d) must be greater than or equal to value of 'min-block-bytes' (%d)", max, min)
 	}
 
+	if *maxRetries < 0 {
+		log.Fatalf("Value of 'max-retries' flag (%d) must be greater than or equal to 0", *maxRetries)
+	}
+
 	if *dbURL == "" {
 		log.Fatal("--db-url flag is required")
 	}
@@ -172,37 +516,119 @@ func main() {
 		log.Fatal(err)
 	}
 
-	lastNow := time.Now()
-	var lastNumDumps uint64
-	writers := make([]blockWriter, *concurrency)
+	workload := newWorkload(db)
+
+	if *listenAddr != "" {
+		http.Handle("/metrics", workload.metrics)
+		go func() {
+			if err := http.ListenAndServe(*listenAddr, nil); err != nil {
+				log.Fatal(err)
+			}
+		}()
+		log.Printf("serving Prometheus metrics at http://%s/metrics", *listenAddr)
+	}
+
+	batches := make([]*batchInsert, *tableCount)
+	selects := make([]*sql.Stmt, *tableCount)
+	for i := range batches {
+		table := fmt.Sprintf("blocks_%d", i)
+		batch, err := newBatchInsert(db, table, *batchSize)
+		if err != nil {
+			log.Fatal(err)
+		}
+		batches[i] = batch
+		sel, err := newSelectBlock(db, table)
+		if err != nil {
+			log.Fatal(err)
+		}
+		selects[i] = sel
+	}
+
+	stopCh := make(chan struct{})
+	sigCh := make(chan os.Signal, 1)
+	signal.Notify(sigCh, os.Interrupt)
+	go func() {
+		<-sigCh
+		log.Print("received interrupt; draining in-flight blocks before exit")
+		close(stopCh)
+	}()
+
+	// readPercent carves reader goroutines out of concurrency rather than
+	// adding them on top, so that e.g. --read-percent=100 dedicates all of
+	// concurrency to verification instead of generating new blocks.
+	numReaders := *concurrency * *readPercent / 100
+	numGenerators := *concurrency - numReaders
+
+	var generatorWG sync.WaitGroup
+	for i := 0; i < numGenerators; i++ {
+		generatorWG.Add(1)
+		go func(i int) {
+			defer generatorWG.Done()
+			workload.runGenerator(i, stopCh)
+		}(i)
+	}
 
 	errCh := make(chan error)
-	for i := range writers {
-		writers[i] = newBlockWriter(db)
-		go writers[i].run(errCh)
+	var writerWG sync.WaitGroup
+	for i := 0; i < *writers; i++ {
+		writerWG.Add(1)
+		go func() {
+			defer writerWG.Done()
+			workload.runWriter(batches, errCh)
+		}()
+	}
+
+	var readerWG sync.WaitGroup
+	for i := 0; i < numReaders; i++ {
+		readerWG.Add(1)
+		go func() {
+			defer readerWG.Done()
+			workload.runReader(selects, errCh, stopCh)
+		}()
 	}
 
+	done := make(chan struct{})
+	go func() {
+		generatorWG.Wait()
+		close(workload.queue)
+		writerWG.Wait()
+		readerWG.Wait()
+		close(done)
+	}()
+
+	lastNow := time.Now()
+	var lastNumDumps uint64
+	lastShardCounts := make([]uint64, *tableCount)
+
 	var numErr int
-	for range time.Tick(*outputInterval) {
-		now := time.Now()
-		elapsed := time.Since(lastNow)
-		dumps := atomic.LoadUint64(&numBlocks)
-		log.Printf("%d dumps were executed at %.1f/second (%d total errors)", (dumps - lastNumDumps), float64(dumps-lastNumDumps)/elapsed.Seconds(), numErr)
-		for {
-			select {
-			case err := <-errCh:
-				numErr++
-				if !*tolerateErrors {
-					log.Fatal(err)
-				} else {
-					log.Print(err)
-				}
-				continue
-			default:
+	ticker := time.Tick(*outputInterval)
+	for {
+		select {
+		case <-done:
+			log.Printf("shutdown complete: %d total blocks written, %d total errors", workload.metrics.written(), numErr)
+			return
+		case err := <-errCh:
+			numErr++
+			if !*tolerateErrors {
+				log.Fatal(err)
+			} else {
+				log.Print(err)
+			}
+		case <-ticker:
+			now := time.Now()
+			elapsed := time.Since(lastNow)
+			dumps := workload.metrics.written()
+			log.Printf("%d dumps were executed at %.1f/second (%d total errors, queue depth=%d, high-water=%d, verify-errors=%d)",
+				(dumps - lastNumDumps), float64(dumps-lastNumDumps)/elapsed.Seconds(), numErr,
+				atomic.LoadInt64(&workload.queueLen), atomic.LoadInt64(&workload.highWater), atomic.LoadUint64(&workload.verifyErrors))
+			for i, stats := range workload.shards {
+				count := atomic.LoadUint64(&stats.blockCount)
+				log.Printf("  shard blocks_%d: %d dumps were executed at %.1f/second (%d errors)",
+					i, count-lastShardCounts[i], float64(count-lastShardCounts[i])/elapsed.Seconds(), atomic.LoadUint64(&stats.errCount))
+				lastShardCounts[i] = count
 			}
-			break
+			lastNumDumps = dumps
+			lastNow = now
 		}
-		lastNumDumps = dumps
-		lastNow = now
 	}
 }
@@ -0,0 +1,121 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+	"sync/atomic"
+)
+
+// latencyBuckets are the upper bounds (in seconds) of the insert-latency
+// histogram, following the default bucket layout used by most Prometheus
+// client libraries.
+var latencyBuckets = []float64{0.005, 0.01, 0.025, 0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10}
+
+// metricsRegistry holds the counters and histogram backing the /metrics
+// endpoint. The console log line reads its written-block count from this
+// registry; its error count, queue depth, verify-errors and per-shard
+// figures are tracked outside the registry and are not exported here.
+type metricsRegistry struct {
+	blocksWritten uint64 // atomic
+	retries       uint64 // atomic
+
+	mu            sync.Mutex
+	blocksFailed  map[string]uint64
+	latencyCounts []uint64
+	latencySum    float64
+	latencyCount  uint64
+}
+
+func newMetricsRegistry() *metricsRegistry {
+	return &metricsRegistry{
+		blocksFailed:  make(map[string]uint64),
+		latencyCounts: make([]uint64, len(latencyBuckets)),
+	}
+}
+
+func (m *metricsRegistry) addWritten(n uint64) {
+	atomic.AddUint64(&m.blocksWritten, n)
+}
+
+func (m *metricsRegistry) written() uint64 {
+	return atomic.LoadUint64(&m.blocksWritten)
+}
+
+func (m *metricsRegistry) addRetries(n uint64) {
+	atomic.AddUint64(&m.retries, n)
+}
+
+func (m *metricsRegistry) addFailed(reason string, n uint64) {
+	m.mu.Lock()
+	m.blocksFailed[reason] += n
+	m.mu.Unlock()
+}
+
+// observeLatency records the duration, in seconds, of a single insert
+// round-trip (covering all of its retries).
+func (m *metricsRegistry) observeLatency(seconds float64) {
+	m.mu.Lock()
+	m.latencySum += seconds
+	m.latencyCount++
+	for i, upperBound := range latencyBuckets {
+		if seconds <= upperBound {
+			m.latencyCounts[i]++
+		}
+	}
+	m.mu.Unlock()
+}
+
+// writeProm renders the registry in the Prometheus text exposition format.
+func (m *metricsRegistry) writeProm(w io.Writer) {
+	fmt.Fprintln(w, "# HELP blocks_written_total Total number of blocks successfully written.")
+	fmt.Fprintln(w, "# TYPE blocks_written_total counter")
+	fmt.Fprintf(w, "blocks_written_total %d\n\n", m.written())
+
+	fmt.Fprintln(w, "# HELP blocks_failed_total Total number of blocks that permanently failed to write, by reason.")
+	fmt.Fprintln(w, "# TYPE blocks_failed_total counter")
+	m.mu.Lock()
+	for reason, n := range m.blocksFailed {
+		fmt.Fprintf(w, "blocks_failed_total{reason=%q} %d\n", reason, n)
+	}
+	m.mu.Unlock()
+	fmt.Fprintln(w)
+
+	fmt.Fprintln(w, "# HELP retries_total Total number of insert retries attempted across all writers.")
+	fmt.Fprintln(w, "# TYPE retries_total counter")
+	fmt.Fprintf(w, "retries_total %d\n\n", atomic.LoadUint64(&m.retries))
+
+	fmt.Fprintln(w, "# HELP block_insert_latency_seconds Latency of block insert round-trips, including retries.")
+	fmt.Fprintln(w, "# TYPE block_insert_latency_seconds histogram")
+	m.mu.Lock()
+	for i, upperBound := range latencyBuckets {
+		fmt.Fprintf(w, "block_insert_latency_seconds_bucket{le=\"%g\"} %d\n", upperBound, m.latencyCounts[i])
+	}
+	fmt.Fprintf(w, "block_insert_latency_seconds_bucket{le=\"+Inf\"} %d\n", m.latencyCount)
+	fmt.Fprintf(w, "block_insert_latency_seconds_sum %g\n", m.latencySum)
+	fmt.Fprintf(w, "block_insert_latency_seconds_count %d\n", m.latencyCount)
+	m.mu.Unlock()
+}
+
+// ServeHTTP implements http.Handler, serving the registry at /metrics in
+// the Prometheus text exposition format.
+func (m *metricsRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	m.writeProm(w)
+}
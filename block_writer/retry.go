@@ -0,0 +1,55 @@
+// Copyright 2015 The Cockroach Authors.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or
+// implied. See the License for the specific language governing
+// permissions and limitations under the License. See the AUTHORS file
+// for names of contributors.
+
+package main
+
+import (
+	"math/rand"
+	"strings"
+	"time"
+)
+
+const (
+	retryBaseDelay = 10 * time.Millisecond
+	retryMaxDelay  = 1 * time.Second
+)
+
+// classifyError distinguishes errors worth retrying (serialization
+// conflicts, dropped connections, expired deadlines) from fatal ones, and
+// returns a short machine-readable reason suitable for the
+// blocks_failed_total{reason=...} metric.
+func classifyError(err error) (retryable bool, reason string) {
+	msg := err.Error()
+	switch {
+	case strings.Contains(msg, "40001"):
+		return true, "serialization_failure"
+	case strings.Contains(msg, "connection reset"), strings.Contains(msg, "broken pipe"):
+		return true, "connection_reset"
+	case strings.Contains(msg, "context deadline exceeded"):
+		return true, "deadline_exceeded"
+	default:
+		return false, "other"
+	}
+}
+
+// backoff returns an exponentially increasing delay with jitter for the
+// given (1-indexed) retry attempt, capped at retryMaxDelay.
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << uint(attempt)
+	if d <= 0 || d > retryMaxDelay {
+		d = retryMaxDelay
+	}
+	return d/2 + time.Duration(rand.Int63n(int64(d)/2+1))
+}